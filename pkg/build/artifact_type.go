@@ -0,0 +1,226 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+)
+
+var (
+	typeAppNode   ArtifactType = "app-node"
+	typeAppPython ArtifactType = "app-python"
+	typeAppRust   ArtifactType = "app-rust"
+	typeLibOci    ArtifactType = "lib-oci"
+)
+
+// ArtifactSpec is the parsed `artifacts[]` entry from the pipeline yaml,
+// before it's been resolved into a constructed Artifact.
+type ArtifactSpec struct {
+	Id           string
+	Path         string
+	Dependencies []string
+	Type         ArtifactType
+}
+
+// ArtifactTypePlugin supplies the behavior for one ArtifactType: how to
+// validate its spec and which steps build/publish it. External callers can
+// add their own with RegisterArtifactType instead of forking this package.
+type ArtifactTypePlugin interface {
+	Validate(spec ArtifactSpec) ValidationErrors
+	BuildSteps(artifact Artifact) []Step
+	PublishSteps(artifact Artifact) []Step
+	Defaults() ArtifactSpec
+}
+
+var artifactTypePlugins = map[ArtifactType]ArtifactTypePlugin{}
+
+// RegisterArtifactType adds or replaces the plugin used for artifacts of the
+// given type. Called from this package's init() for the built-ins, and
+// available to user code to add their own.
+func RegisterArtifactType(name string, plugin ArtifactTypePlugin) {
+	artifactTypePlugins[ArtifactType(name)] = plugin
+}
+
+func init() {
+	RegisterArtifactType(string(typeAppGo), appGoArtifactType{})
+	RegisterArtifactType(string(typeLibGo), libGoArtifactType{})
+	RegisterArtifactType(string(typeAppNode), appNodeArtifactType{})
+	RegisterArtifactType(string(typeAppPython), appPythonArtifactType{})
+	RegisterArtifactType(string(typeAppRust), appRustArtifactType{})
+	RegisterArtifactType(string(typeLibOci), libOciArtifactType{})
+}
+
+// UnknownArtifactType plays the role for artifact types that
+// SecretProviderTypeEnum.InvalidEnumValue plays for secret providers: one
+// error, raised as soon as an unregistered type is decoded, that lists every
+// type actually registered.
+func UnknownArtifactType(given string) error {
+	names := make([]string, 0, len(artifactTypePlugins))
+	for t := range artifactTypePlugins {
+		names = append(names, string(t))
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown artifact type %q, must be one of %v", given, names)
+}
+
+func (t *ArtifactType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	parsed := ArtifactType(raw)
+	if _, ok := artifactTypePlugins[parsed]; !ok {
+		return UnknownArtifactType(raw)
+	}
+
+	*t = parsed
+	return nil
+}
+
+// validatePathSpec is the shared Validate body for every built-in artifact
+// type: they all require nothing but a Path.
+func validatePathSpec(spec ArtifactSpec) ValidationErrors {
+	errs := NewValidationErrors(spec.Id)
+	if spec.Path == "" {
+		errs = errs.Put("path", eMissingRequiredField)
+	}
+	return errs
+}
+
+type appGoArtifactType struct{}
+
+func (appGoArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (appGoArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "go build", Run: fmt.Sprintf("go build ./%s/...", artifact.Path)},
+		{Name: "docker build", Run: fmt.Sprintf("docker build -t %s %s", artifact.Repository, artifact.Path)},
+	}
+}
+
+func (appGoArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "docker push", Run: fmt.Sprintf("docker push %s", artifact.Repository)},
+	}
+}
+
+func (appGoArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeAppGo}
+}
+
+type libGoArtifactType struct{}
+
+func (libGoArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (libGoArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "go test", Run: fmt.Sprintf("go test ./%s/...", artifact.Path)},
+	}
+}
+
+func (libGoArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "tag release", Run: fmt.Sprintf("git tag %s/%s", artifact.Id, artifact.CurrentSha)},
+	}
+}
+
+func (libGoArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeLibGo}
+}
+
+type appNodeArtifactType struct{}
+
+func (appNodeArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (appNodeArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "npm ci", Run: fmt.Sprintf("npm --prefix %s ci", artifact.Path)},
+		{Name: "docker build", Run: fmt.Sprintf("docker build -t %s %s", artifact.Repository, artifact.Path)},
+	}
+}
+
+func (appNodeArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "docker push", Run: fmt.Sprintf("docker push %s", artifact.Repository)},
+	}
+}
+
+func (appNodeArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeAppNode}
+}
+
+type appPythonArtifactType struct{}
+
+func (appPythonArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (appPythonArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "pip install", Run: fmt.Sprintf("pip install -r %s/requirements.txt", artifact.Path)},
+		{Name: "docker build", Run: fmt.Sprintf("docker build -t %s %s", artifact.Repository, artifact.Path)},
+	}
+}
+
+func (appPythonArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "docker push", Run: fmt.Sprintf("docker push %s", artifact.Repository)},
+	}
+}
+
+func (appPythonArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeAppPython}
+}
+
+type appRustArtifactType struct{}
+
+func (appRustArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (appRustArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "cargo build", Run: fmt.Sprintf("cargo build --release --manifest-path %s/Cargo.toml", artifact.Path)},
+		{Name: "docker build", Run: fmt.Sprintf("docker build -t %s %s", artifact.Repository, artifact.Path)},
+	}
+}
+
+func (appRustArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "docker push", Run: fmt.Sprintf("docker push %s", artifact.Repository)},
+	}
+}
+
+func (appRustArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeAppRust}
+}
+
+// libOciArtifactType builds an OCI image directly from a build context with
+// buildah, without requiring a Dockerfile.
+type libOciArtifactType struct{}
+
+func (libOciArtifactType) Validate(spec ArtifactSpec) ValidationErrors {
+	return validatePathSpec(spec)
+}
+
+func (libOciArtifactType) BuildSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "buildah build", Run: fmt.Sprintf("buildah bud -t %s %s", artifact.Repository, artifact.Path)},
+	}
+}
+
+func (libOciArtifactType) PublishSteps(artifact Artifact) []Step {
+	return []Step{
+		{Name: "buildah push", Run: fmt.Sprintf("buildah push %s", artifact.Repository)},
+	}
+}
+
+func (libOciArtifactType) Defaults() ArtifactSpec {
+	return ArtifactSpec{Type: typeLibOci}
+}
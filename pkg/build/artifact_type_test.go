@@ -0,0 +1,37 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestArtifactTypeUnmarshalYAML(t *testing.T) {
+	var parsed ArtifactType
+	err := yaml.Unmarshal([]byte("app-go"), &parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, typeAppGo, parsed)
+
+	err = yaml.Unmarshal([]byte("cobol"), &parsed)
+	assert.Equal(t, UnknownArtifactType("cobol"), err)
+}
+
+func TestRegisterArtifactType(t *testing.T) {
+	RegisterArtifactType("my-type", appGoArtifactType{})
+	defer delete(artifactTypePlugins, ArtifactType("my-type"))
+
+	var parsed ArtifactType
+	err := yaml.Unmarshal([]byte("my-type"), &parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, ArtifactType("my-type"), parsed)
+}
+
+func TestArtifactTypeValidate(t *testing.T) {
+	errs := appGoArtifactType{}.Validate(ArtifactSpec{Id: "svc"})
+	assert.Equal(t, true, errs.IsPresent())
+	assert.Equal(t, NewValidationErrors("svc").Put("path", eMissingRequiredField), errs)
+
+	errs = libOciArtifactType{}.Validate(ArtifactSpec{Id: "svc", Path: "./svc"})
+	assert.Equal(t, false, errs.IsPresent())
+}
@@ -0,0 +1,178 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/itura/fun/pkg/fun"
+)
+
+type CloudProviderType string
+
+var (
+	cloudProviderTypeGcp   CloudProviderType = "gcp"
+	cloudProviderTypeAws   CloudProviderType = "aws"
+	cloudProviderTypeAzure CloudProviderType = "azure"
+)
+
+var CloudProviderTypeEnum = fun.NewEnum(cloudProviderTypeGcp, cloudProviderTypeAws, cloudProviderTypeAzure)
+
+// CloudProviderPlugin supplies the behavior for one CloudProviderType: the
+// config fields it requires and how to compute its registry URL and cluster
+// login step. External callers can add their own with RegisterCloudProvider
+// instead of forking this package, the same pattern ArtifactTypePlugin uses.
+type CloudProviderPlugin interface {
+	RequiredFields() []string
+	ArtifactRegistryURL(config CloudProviderConfig, repo ArtifactRepository) string
+	KubernetesLoginStep(config CloudProviderConfig) Step
+}
+
+var cloudProviderPlugins = map[CloudProviderType]CloudProviderPlugin{}
+
+// RegisterCloudProvider adds or replaces the plugin used for cloud providers
+// of the given type.
+func RegisterCloudProvider(name string, plugin CloudProviderPlugin) {
+	cloudProviderPlugins[CloudProviderType(name)] = plugin
+}
+
+func init() {
+	RegisterCloudProvider(string(cloudProviderTypeGcp), GcpCloudProvider{})
+	RegisterCloudProvider(string(cloudProviderTypeAws), AwsCloudProvider{})
+	RegisterCloudProvider(string(cloudProviderTypeAzure), AzureCloudProvider{})
+}
+
+// UnmarshalYAML rejects an unrecognized cloudProvider.type at decode time,
+// the same way ArtifactType and SecretProviderType do.
+func (t *CloudProviderType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	parsed := CloudProviderType(raw)
+	if _, ok := cloudProviderPlugins[parsed]; !ok {
+		return CloudProviderTypeEnum.InvalidEnumValue(raw)
+	}
+
+	*t = parsed
+	return nil
+}
+
+// CloudProviderConfig describes the cloud a pipeline's resources live in. The
+// behavior for each Type is dispatched to its registered CloudProviderPlugin
+// rather than switched on here.
+type CloudProviderConfig struct {
+	Type   CloudProviderType
+	Config fun.Config[string]
+}
+
+func CloudProviderMissingField(providerType string) error {
+	return fmt.Errorf("missing required field for cloud provider of type %s", providerType)
+}
+
+func (c CloudProviderConfig) Validate(path string) ValidationErrors {
+	errs := NewValidationErrors(path)
+
+	plugin, ok := cloudProviderPlugins[c.Type]
+	if !ok {
+		errs = errs.Put("type", CloudProviderTypeEnum.InvalidEnumValue(string(c.Type)))
+		return errs
+	}
+
+	configErrs := NewValidationErrors("config")
+	for _, field := range plugin.RequiredFields() {
+		if _, ok := c.Config[field]; !ok {
+			configErrs = configErrs.Put(field, CloudProviderMissingField(string(c.Type)))
+		}
+	}
+	if configErrs.IsPresent() {
+		errs = errs.PutChild(configErrs)
+	}
+
+	return errs
+}
+
+// ArtifactRegistryURL returns the fully-qualified image repository URL for
+// this provider's artifact registry flavor: Artifact Registry for gcp, ECR
+// for aws, ACR for azure.
+func (c CloudProviderConfig) ArtifactRegistryURL(repo ArtifactRepository) string {
+	if plugin, ok := cloudProviderPlugins[c.Type]; ok {
+		return plugin.ArtifactRegistryURL(c, repo)
+	}
+	return fmt.Sprintf("%s/%s/%s", repo.Host, c.Config["project"], repo.Name)
+}
+
+// KubernetesLoginStep returns the CI step that authenticates kubectl/helm
+// against this provider's managed cluster.
+func (c CloudProviderConfig) KubernetesLoginStep() Step {
+	if plugin, ok := cloudProviderPlugins[c.Type]; ok {
+		return plugin.KubernetesLoginStep(c)
+	}
+	return Step{}
+}
+
+type GcpCloudProvider struct{}
+
+func (GcpCloudProvider) RequiredFields() []string {
+	return []string{"serviceAccount", "workloadIdentityProvider"}
+}
+
+func (GcpCloudProvider) ArtifactRegistryURL(config CloudProviderConfig, repo ArtifactRepository) string {
+	return fmt.Sprintf("%s/%s/%s", repo.Host, config.Config["project"], repo.Name)
+}
+
+func (GcpCloudProvider) KubernetesLoginStep(config CloudProviderConfig) Step {
+	return Step{
+		Name: "Login to GKE",
+		Uses: "google-github-actions/auth@v2",
+		With: map[string]string{
+			"service_account":            config.Config["serviceAccount"],
+			"workload_identity_provider": config.Config["workloadIdentityProvider"],
+		},
+		Run: fmt.Sprintf("gcloud auth activate-service-account %s --workload-identity-provider=%s", config.Config["serviceAccount"], config.Config["workloadIdentityProvider"]),
+	}
+}
+
+type AwsCloudProvider struct{}
+
+func (AwsCloudProvider) RequiredFields() []string {
+	return []string{"iamRoleArn", "oidcAudience", "accountId", "region"}
+}
+
+func (AwsCloudProvider) ArtifactRegistryURL(config CloudProviderConfig, repo ArtifactRepository) string {
+	return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s", config.Config["accountId"], config.Config["region"], repo.Name)
+}
+
+func (AwsCloudProvider) KubernetesLoginStep(config CloudProviderConfig) Step {
+	return Step{
+		Name: "Login to EKS",
+		Uses: "aws-actions/configure-aws-credentials@v4",
+		With: map[string]string{
+			"role-to-assume": config.Config["iamRoleArn"],
+			"audience":       config.Config["oidcAudience"],
+		},
+		Run: fmt.Sprintf("aws sts assume-role --role-arn %s --role-session-name ci", config.Config["iamRoleArn"]),
+	}
+}
+
+type AzureCloudProvider struct{}
+
+func (AzureCloudProvider) RequiredFields() []string {
+	return []string{"tenantId", "clientId", "subscriptionId"}
+}
+
+func (AzureCloudProvider) ArtifactRegistryURL(config CloudProviderConfig, repo ArtifactRepository) string {
+	return fmt.Sprintf("%s.azurecr.io/%s", config.Config["registry"], repo.Name)
+}
+
+func (AzureCloudProvider) KubernetesLoginStep(config CloudProviderConfig) Step {
+	return Step{
+		Name: "Login to AKS",
+		Uses: "azure/login@v2",
+		With: map[string]string{
+			"tenant-id":       config.Config["tenantId"],
+			"client-id":       config.Config["clientId"],
+			"subscription-id": config.Config["subscriptionId"],
+		},
+		Run: fmt.Sprintf("az login --service-principal -u %s -t %s --federated-token $AZURE_FEDERATED_TOKEN_FILE", config.Config["clientId"], config.Config["tenantId"]),
+	}
+}
@@ -3,6 +3,7 @@ package build
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/itura/fun/pkg/fun"
 
@@ -20,8 +21,6 @@ type ArtifactType string
 var (
 	typeLibGo ArtifactType = "lib-go"
 	typeAppGo ArtifactType = "app-go"
-	typeApp   ArtifactType = "app"
-	typeLib   ArtifactType = "lib"
 )
 
 type ApplicationType string
@@ -34,10 +33,43 @@ var (
 type SecretProviderType string
 
 var (
-	typeGcp    SecretProviderType = "gcp"
-	typeGithub SecretProviderType = "github-actions"
+	secretProviderTypeGcp               SecretProviderType = "gcp"
+	secretProviderTypeGithub            SecretProviderType = "github-actions"
+	secretProviderTypeVault             SecretProviderType = "vault"
+	secretProviderTypeAwsSecretsManager SecretProviderType = "aws-secrets-manager"
+	secretProviderTypeAzureKeyVault     SecretProviderType = "azure-keyvault"
+	secretProviderTypeExec              SecretProviderType = "exec"
 )
 
+var SecretProviderTypeEnum = fun.NewEnum(
+	secretProviderTypeGcp,
+	secretProviderTypeGithub,
+	secretProviderTypeVault,
+	secretProviderTypeAwsSecretsManager,
+	secretProviderTypeAzureKeyVault,
+	secretProviderTypeExec,
+)
+
+// UnmarshalYAML rejects an unrecognized secretProviders[].type at decode
+// time, the same way a hand-written enum would, so callers never have to
+// special-case a half-decoded SecretProviderType. Validity is driven by the
+// registered SecretProviderPlugins (see secret_provider.go), the same
+// pluggable-by-type pattern ArtifactType and CloudProviderType use.
+func (t *SecretProviderType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	parsed := SecretProviderType(raw)
+	if _, ok := secretProviderPlugins[parsed]; !ok {
+		return SecretProviderTypeEnum.InvalidEnumValue(raw)
+	}
+
+	*t = parsed
+	return nil
+}
+
 type ClusterConfig struct {
 	Name     string
 	Location string
@@ -48,17 +80,71 @@ type ArtifactRepository struct {
 	Name string
 }
 
-type CloudProvider struct {
-	Type   string
-	Config map[string]string
+type SecretProviderConfig struct {
+	Id          string
+	Type        SecretProviderType
+	Config      fun.Config[string]
+	SecretNames []string "yaml:\"secretNames\""
 }
 
-type SecretProvider struct {
-	Type   SecretProviderType
-	Config map[string]string
+func (s SecretProviderConfig) Validate(path string) ValidationErrors {
+	errs := NewValidationErrors(path)
+
+	if s.Id == "" {
+		errs = errs.Put("id", eMissingRequiredField)
+	}
+
+	var requiredFields []string
+	if plugin, ok := secretProviderPlugins[s.Type]; ok {
+		requiredFields = plugin.RequiredFields()
+	} else if s.Type == "" {
+		errs = errs.Put("type", eMissingRequiredField)
+	} else {
+		errs = errs.Put("type", SecretProviderTypeEnum.InvalidEnumValue(string(s.Type)))
+	}
+
+	if len(s.SecretNames) == 0 {
+		errs = errs.Put("secretNames", eMissingRequiredField)
+	}
+
+	if len(requiredFields) > 0 {
+		if s.Config == nil {
+			errs = errs.Put("config", eMissingRequiredField)
+		} else {
+			configErrs := NewValidationErrors("config")
+			for _, field := range requiredFields {
+				if _, ok := s.Config[field]; !ok {
+					configErrs = configErrs.Put(field, eMissingRequiredField)
+				}
+			}
+			if configErrs.IsPresent() {
+				errs = errs.PutChild(configErrs)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s SecretProviderConfig) hasSecretName(name string) bool {
+	for _, n := range s.SecretNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
-type SecretProviders fun.Config[SecretProvider]
+type SecretProviderConfigs []SecretProviderConfig
+
+func (s SecretProviderConfigs) find(id string) (SecretProviderConfig, bool) {
+	for _, provider := range s {
+		if provider.Id == id {
+			return provider, true
+		}
+	}
+	return SecretProviderConfig{}, false
+}
 
 type SecretConfig struct {
 	HelmKey    string "yaml:\"helmKey\""
@@ -66,30 +152,123 @@ type SecretConfig struct {
 	Provider   string
 }
 
+type Resources struct {
+	ArtifactRepository ArtifactRepository    `yaml:"artifactRepository"`
+	KubernetesCluster  ClusterConfig         `yaml:"kubernetesCluster"`
+	SecretProviders    SecretProviderConfigs `yaml:"secretProviders"`
+	CloudProvider      CloudProviderConfig   `yaml:"cloudProvider"`
+}
+
+func (r Resources) Validate(path string) ValidationErrors {
+	errs := NewValidationErrors(path)
+
+	if r.CloudProvider.Type == "" {
+		errs = errs.Put("cloudProvider", eMissingRequiredField)
+	} else if cpErrs := r.CloudProvider.Validate("cloudProvider"); cpErrs.IsPresent() {
+		errs = errs.PutChild(cpErrs)
+	}
+
+	if r.KubernetesCluster == (ClusterConfig{}) {
+		errs = errs.Put("kubernetesCluster", eMissingRequiredField)
+	}
+
+	repositoryErrs := NewValidationErrors("artifactRepository")
+	if r.ArtifactRepository.Host == "" {
+		repositoryErrs = repositoryErrs.Put("host", eMissingRequiredField)
+	}
+	if repositoryErrs.IsPresent() {
+		errs = errs.PutChild(repositoryErrs)
+	}
+
+	providersErrs := NewValidationErrors("secretProviders")
+	for i, provider := range r.SecretProviders {
+		if providerErrs := provider.Validate(strconv.Itoa(i)); providerErrs.IsPresent() {
+			providersErrs = providersErrs.PutChild(providerErrs)
+		}
+	}
+	if providersErrs.IsPresent() {
+		errs = errs.PutChild(providersErrs)
+	}
+
+	return errs
+}
+
+// ApplicationSpec is the parsed `applications[]` entry from the pipeline
+// yaml, before it's been resolved into a constructed Application.
+type ApplicationSpec struct {
+	Id           string
+	Path         string
+	Namespace    string
+	Artifacts    []string
+	Values       []HelmValue
+	Secrets      []SecretConfig
+	Dependencies []string
+	Type         ApplicationType
+}
+
 type PipelineConfig struct {
-	Name      string
-	Resources struct {
-		ArtifactRepository ArtifactRepository `yaml:"artifactRepository"`
-		KubernetesCluster  ClusterConfig      `yaml:"kubernetesCluster"`
-		SecretProviders    SecretProviders    `yaml:"secretProviders"`
-		CloudProvider      CloudProvider      `yaml:"cloudProvider"`
-	}
-	Artifacts []struct {
-		Id           string
-		Path         string
-		Dependencies []string
-		Type         ArtifactType
-	}
-	Applications []struct {
-		Id           string
-		Path         string
-		Namespace    string
-		Artifacts    []string
-		Values       []HelmValue
-		Secrets      []SecretConfig
-		Dependencies []string
-		Type         ApplicationType
+	Name     string
+	Pipeline struct {
+		Target PipelineTarget
 	}
+	Resources    Resources
+	Artifacts    []ArtifactSpec
+	Applications []ApplicationSpec
+}
+
+// Validate walks the entire pipeline config - resources, every artifact,
+// every application and its secret references - and accumulates every
+// failure it finds instead of stopping at the first one.
+func (c PipelineConfig) Validate() ValidationErrors {
+	errs := NewValidationErrors("")
+
+	if resourcesErrs := c.Resources.Validate("resources"); resourcesErrs.IsPresent() {
+		errs = errs.PutChild(resourcesErrs)
+	}
+
+	if _, dagErr := c.TopoOrder(); dagErr != nil {
+		errs = errs.Put("dependencies", dagErr)
+	}
+
+	artifactsErrs := NewValidationErrors("artifacts")
+	for _, spec := range c.Artifacts {
+		// Unregistered types are already rejected at decode time by
+		// ArtifactType.UnmarshalYAML. An artifact whose type is omitted has
+		// no plugin and is not validated here.
+		if plugin, ok := artifactTypePlugins[spec.Type]; ok {
+			if specErrs := plugin.Validate(spec); specErrs.IsPresent() {
+				artifactsErrs = artifactsErrs.PutChild(specErrs)
+			}
+		}
+	}
+	if artifactsErrs.IsPresent() {
+		errs = errs.PutChild(artifactsErrs)
+	}
+
+	applicationsErrs := NewValidationErrors("applications")
+	for _, spec := range c.Applications {
+		applicationErrs := NewValidationErrors(spec.Id)
+
+		secretsErrs := NewValidationErrors("secrets")
+		for _, secretConfig := range spec.Secrets {
+			provider, ok := c.Resources.SecretProviders.find(secretConfig.Provider)
+			if !ok || !provider.hasSecretName(secretConfig.SecretName) {
+				secretsErrs = secretsErrs.Put(secretConfig.HelmKey, fmt.Errorf("secret '%s' not configured in any secretProvider", secretConfig.SecretName))
+			}
+		}
+		if secretsErrs.IsPresent() {
+			applicationErrs = applicationErrs.PutChild(secretsErrs)
+		}
+
+		if applicationErrs.IsPresent() {
+			applicationsErrs = applicationsErrs.PutChild(applicationErrs)
+		}
+	}
+	if applicationsErrs.IsPresent() {
+		errs = errs.PutChild(applicationsErrs)
+	}
+
+	return errs
 }
 
 func _parsePipelineConfig(configPath string) (PipelineConfig, error) {
@@ -113,98 +292,99 @@ func parseConfig(args ActionArgs, previousSha string) ParsedConfig {
 		return FailedParse("", err)
 	}
 
-	cloudProvider := config.Resources.CloudProvider
-
-	if cloudProvider.Type == "gcp" {
-		_, ok := cloudProvider.Config["serviceAccount"]
-		if !ok {
-			return FailedParse(config.Name, fmt.Errorf("No service account configured for cloud provider of type %s", cloudProvider.Type))
-		}
-
-		_, ok = cloudProvider.Config["workloadIdentityProvider"]
-		if !ok {
-			return FailedParse(config.Name, fmt.Errorf("No Workload Identity Provider configured for cloud provider of type %s", cloudProvider.Type))
-		}
-	} else {
-		return FailedParse(config.Name, InvalidCloudProvider{"Missing/Unknown"})
+	if errs := config.Validate(); errs.IsPresent() {
+		return FailedParse(config.Name, errs)
 	}
 
-	var providerConfigs map[string]SecretProvider = config.Resources.SecretProviders
+	cloudProvider := config.Resources.CloudProvider
+	secretProviders := config.Resources.SecretProviders
+	repository := cloudProvider.ArtifactRegistryURL(config.Resources.ArtifactRepository)
 
-	// TODO extract
-	for _, provider := range providerConfigs {
-		if provider.Type != typeGcp && provider.Type != typeGithub {
-			return FailedParse(config.Name, InvalidSecretProviderType{GivenType: string(provider.Type)})
-		}
+	artifactSpecs := make(map[string]ArtifactSpec, len(config.Artifacts))
+	for _, spec := range config.Artifacts {
+		artifactSpecs[spec.Id] = spec
+	}
+	applicationSpecs := make(map[string]ApplicationSpec, len(config.Applications))
+	for _, spec := range config.Applications {
+		applicationSpecs[spec.Id] = spec
 	}
 
-	var repository string = fmt.Sprintf("%s/%s/%s", config.Resources.ArtifactRepository.Host, cloudProvider.Config["project"], config.Resources.ArtifactRepository.Name)
+	// Validate already ran the DAG pass above, so this can't fail here - it
+	// exists only to fix what the old "todo make agnostic to ordering"
+	// comment flagged: artifacts/applications must be built in an order
+	// where every dependency already exists.
+	order, _ := config.TopoOrder()
+
+	// transitivePaths[id] is every path id's change detection should watch:
+	// its own Path plus every dependency's transitivePaths, so a change deep
+	// in the dependency chain still marks its dependents as changed.
+	transitivePaths := make(map[string][]string, len(order))
+	artifacts := make(map[string]Artifact, len(config.Artifacts))
+	applications := make(map[string]Application, len(config.Applications))
+
+	for _, id := range order {
+		if spec, ok := artifactSpecs[id]; ok {
+			paths := append([]string{spec.Path}, transitiveDepPaths(spec.Dependencies, transitivePaths)...)
+			transitivePaths[id] = paths
+
+			var upstreams []Job
+			for _, depId := range spec.Dependencies {
+				upstreams = append(upstreams, artifacts[depId])
+			}
 
-	artifacts := make(map[string]Artifact)
-	for _, spec := range config.Artifacts {
-		var upstreams []Job
-		var cd ChangeDetection
-		if args.Force {
-			cd = NewAlwaysChanged()
-		} else {
-			_cd := NewGitChangeDetection(previousSha).
-				AddPaths(spec.Path)
+			var cd ChangeDetection
+			if args.Force {
+				cd = NewAlwaysChanged()
+			} else {
+				_cd := NewGitChangeDetection(previousSha)
+				for _, path := range paths {
+					_cd = _cd.AddPaths(path)
+				}
+				cd = _cd
+			}
 
-			// todo make agnostic to ordering
-			for _, id := range spec.Dependencies {
-				_cd = _cd.AddPaths(artifacts[id].Path)
-				upstreams = append(upstreams, artifacts[id])
+			artifacts[id] = Artifact{
+				Type:            spec.Type,
+				Id:              spec.Id,
+				Path:            spec.Path,
+				Project:         args.ProjectId,
+				Repository:      repository,
+				Host:            config.Resources.ArtifactRepository.Host,
+				CurrentSha:      args.CurrentSha,
+				hasDependencies: len(spec.Dependencies) > 0,
+				Upstreams:       upstreams,
+				hasChanged:      cd.HasChanged(),
+				CloudProvider:   cloudProvider,
 			}
-			cd = _cd
+			continue
 		}
 
-		artifacts[spec.Id] = Artifact{
-			Type:            spec.Type,
-			Id:              spec.Id,
-			Path:            spec.Path,
-			Project:         args.ProjectId,
-			Repository:      repository,
-			Host:            config.Resources.ArtifactRepository.Host,
-			CurrentSha:      args.CurrentSha,
-			hasDependencies: len(spec.Dependencies) > 0,
-			Upstreams:       upstreams,
-			hasChanged:      cd.HasChanged(),
-			CloudProvider:   config.Resources.CloudProvider,
-		}
-	}
+		spec := applicationSpecs[id]
+		deps := append(append([]string{}, spec.Artifacts...), spec.Dependencies...)
+		paths := append([]string{spec.Path}, transitiveDepPaths(deps, transitivePaths)...)
+		transitivePaths[id] = paths
 
-	applications := make(map[string]Application)
-	for _, spec := range config.Applications {
 		var upstreams []Job
+		for _, depId := range spec.Artifacts {
+			upstreams = append(upstreams, artifacts[depId])
+		}
+		for _, depId := range spec.Dependencies {
+			upstreams = append(upstreams, applications[depId])
+		}
+
 		var cd ChangeDetection
 		if args.Force {
 			cd = NewAlwaysChanged()
 		} else {
-			_cd := NewGitChangeDetection(previousSha).
-				AddPaths(spec.Path)
-
-			// todo make agnostic to ordering
-			for _, id := range spec.Artifacts {
-				_cd = _cd.AddPaths(artifacts[id].Path)
-				upstreams = append(upstreams, artifacts[id])
-			}
-			for _, id := range spec.Dependencies {
-				_cd = _cd.AddPaths(applications[id].Path)
-				upstreams = append(upstreams, applications[id])
+			_cd := NewGitChangeDetection(previousSha)
+			for _, path := range paths {
+				_cd = _cd.AddPaths(path)
 			}
 			cd = _cd
 		}
 
-		var secretConfigs = spec.Secrets
-
-		helmSecretValues := make(map[string][]HelmSecretValue, len(secretConfigs))
-		for _, secretConfig := range secretConfigs {
-			_, ok := providerConfigs[secretConfig.Provider]
-
-			if !ok {
-				return FailedParse(config.Name, MissingSecretProvider{})
-			}
-
+		helmSecretValues := make(map[string][]HelmSecretValue, len(spec.Secrets))
+		for _, secretConfig := range spec.Secrets {
 			helmSecretValue := HelmSecretValue{
 				HelmKey:    secretConfig.HelmKey,
 				SecretName: secretConfig.SecretName,
@@ -215,7 +395,7 @@ func parseConfig(args ActionArgs, previousSha string) ParsedConfig {
 		}
 
 		hasDependencies := len(spec.Dependencies) > 0 || len(spec.Artifacts) > 0
-		applications[spec.Id] = Application{
+		applications[id] = Application{
 			Type:              spec.Type,
 			Id:                spec.Id,
 			Path:              spec.Path,
@@ -228,11 +408,23 @@ func parseConfig(args ActionArgs, previousSha string) ParsedConfig {
 			hasDependencies:   hasDependencies,
 			KubernetesCluster: config.Resources.KubernetesCluster,
 			Secrets:           helmSecretValues,
-			SecretProviders:   providerConfigs,
+			SecretProviders:   secretProviders,
 			hasChanged:        cd.HasChanged(),
-			CloudProvider:     config.Resources.CloudProvider,
+			CloudProvider:     cloudProvider,
 		}
 	}
 
-	return SuccessfulParse(config.Name, artifacts, applications)
+	return SuccessfulParse(config.Name, config.Pipeline.Target, artifacts, applications)
+}
+
+// transitiveDepPaths collects the already-computed path sets of each
+// dependency id (safe because we're called in topo order, so they're always
+// populated already), giving the full transitive closure rather than just
+// each dependency's own path.
+func transitiveDepPaths(depIds []string, transitivePaths map[string][]string) []string {
+	var paths []string
+	for _, id := range depIds {
+		paths = append(paths, transitivePaths[id]...)
+	}
+	return paths
 }
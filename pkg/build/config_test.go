@@ -23,10 +23,12 @@ func TestParseConfig(t *testing.T) {
 		{
 			name: "InvalidSecretName",
 			args: TestArgs("test_fixtures/invalid_secret_name.yaml"),
-			expected: FailedParse("My Build", NewValidationErrors("applications").
-				PutChild(NewValidationErrors("db").
-					PutChild(NewValidationErrors("secrets").
-						Put("postgresql.auth.postgresPassword", fmt.Errorf("secret 'beepboop' not configured in any secretProvider")),
+			expected: FailedParse("My Build", NewValidationErrors("").
+				PutChild(NewValidationErrors("applications").
+					PutChild(NewValidationErrors("db").
+						PutChild(NewValidationErrors("secrets").
+							Put("postgresql.auth.postgresPassword", fmt.Errorf("secret 'beepboop' not configured in any secretProvider")),
+						),
 					),
 				),
 			),
@@ -76,7 +78,7 @@ func TestParseConfig(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := parseConfig(tc.args, NewAlwaysChanged())
+			result := parseConfig(tc.args, "")
 			assert.Equal(t, tc.expected.BuildName, result.BuildName)
 			assert.Equal(t, tc.expected.Artifacts, result.Artifacts)
 			assert.Equal(t, tc.expected.Applications, result.Applications)
@@ -136,6 +138,67 @@ func TestCloudProviderValidations(t *testing.T) {
 				Put("workloadIdentityProvider", CloudProviderMissingField("gcp"))),
 		errs,
 	)
+
+	cp = CloudProviderConfig{
+		Type: cloudProviderTypeAws,
+		Config: fun.NewConfig[string]().
+			Set("iamRoleArn", "arn:aws:iam::123456789012:role/deploy"),
+	}
+	errs = cp.Validate("cloudProvider")
+	assert.Equal(t, true, errs.IsPresent())
+	assert.Equal(t,
+		NewValidationErrors("cloudProvider").
+			PutChild(NewValidationErrors("config").
+				Put("oidcAudience", CloudProviderMissingField("aws")).
+				Put("accountId", CloudProviderMissingField("aws")).
+				Put("region", CloudProviderMissingField("aws"))),
+		errs,
+	)
+
+	cp = CloudProviderConfig{
+		Type: cloudProviderTypeAws,
+		Config: fun.NewConfig[string]().
+			Set("iamRoleArn", "arn:aws:iam::123456789012:role/deploy").
+			Set("oidcAudience", "sts.amazonaws.com").
+			Set("accountId", "123456789012").
+			Set("region", "us-east-1"),
+	}
+	errs = cp.Validate("cloudProvider")
+	assert.Equal(t, false, errs.IsPresent())
+
+	cp = CloudProviderConfig{
+		Type: cloudProviderTypeAzure,
+		Config: fun.NewConfig[string]().
+			Set("tenantId", "tenant").
+			Set("clientId", "client").
+			Set("subscriptionId", "subscription"),
+	}
+	errs = cp.Validate("cloudProvider")
+	assert.Equal(t, false, errs.IsPresent())
+}
+
+func TestCloudProviderArtifactRegistryURL(t *testing.T) {
+	repo := ArtifactRepository{Host: "us-docker.pkg.dev", Name: "my-repo"}
+
+	gcp := CloudProviderConfig{
+		Type:   cloudProviderTypeGcp,
+		Config: fun.NewConfig[string]().Set("project", "my-project"),
+	}
+	assert.Equal(t, "us-docker.pkg.dev/my-project/my-repo", gcp.ArtifactRegistryURL(repo))
+
+	aws := CloudProviderConfig{
+		Type: cloudProviderTypeAws,
+		Config: fun.NewConfig[string]().
+			Set("accountId", "123456789012").
+			Set("region", "us-east-1"),
+	}
+	assert.Equal(t, "123456789012.dkr.ecr.us-east-1.amazonaws.com/my-repo", aws.ArtifactRegistryURL(repo))
+
+	azure := CloudProviderConfig{
+		Type:   cloudProviderTypeAzure,
+		Config: fun.NewConfig[string]().Set("registry", "myregistry"),
+	}
+	assert.Equal(t, "myregistry.azurecr.io/my-repo", azure.ArtifactRegistryURL(repo))
 }
 
 func TestResourcesValidation(t *testing.T) {
@@ -0,0 +1,224 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dagNode is the id/dependency-ids shape shared by both artifacts and
+// applications, which is all a topological sort needs to know about either.
+type dagNode struct {
+	id   string
+	deps []string
+}
+
+type UnknownDependency struct {
+	Id         string
+	Dependency string
+}
+
+func (e UnknownDependency) Error() string {
+	return fmt.Sprintf("%s depends on unknown id %s", e.Id, e.Dependency)
+}
+
+type DuplicateId struct {
+	Id string
+}
+
+func (e DuplicateId) Error() string {
+	return fmt.Sprintf("duplicate id %s: artifact and application ids must be unique", e.Id)
+}
+
+type CircularDependency struct {
+	Cycle []string
+}
+
+func (e CircularDependency) Error() string {
+	return fmt.Sprintf("circular dependency: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// topoSort runs Kahn's algorithm over nodes, breaking ties by the nodes'
+// original order so the result is deterministic across runs. It returns
+// DuplicateId if two nodes share an id, UnknownDependency if a dep doesn't
+// resolve to a declared node, and CircularDependency (naming only the ids
+// that are actually part of a cycle, not everything downstream of one) if
+// the graph isn't a DAG.
+func topoSort(nodes []dagNode) ([]string, error) {
+	declared := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if declared[n.id] {
+			return nil, DuplicateId{Id: n.id}
+		}
+		declared[n.id] = true
+	}
+
+	for _, n := range nodes {
+		for _, dep := range n.deps {
+			if !declared[dep] {
+				return nil, UnknownDependency{Id: n.id, Dependency: dep}
+			}
+		}
+	}
+
+	indegree := make(map[string]int, len(nodes))
+	dependents := make(map[string][]string, len(nodes))
+	for _, n := range nodes {
+		if _, ok := indegree[n.id]; !ok {
+			indegree[n.id] = 0
+		}
+		for _, dep := range n.deps {
+			indegree[n.id]++
+			dependents[dep] = append(dependents[dep], n.id)
+		}
+	}
+
+	visited := make(map[string]bool, len(nodes))
+	order := make([]string, 0, len(nodes))
+
+	for len(order) < len(nodes) {
+		progressed := false
+		for _, n := range nodes {
+			if visited[n.id] || indegree[n.id] != 0 {
+				continue
+			}
+			visited[n.id] = true
+			order = append(order, n.id)
+			progressed = true
+			for _, dependent := range dependents[n.id] {
+				indegree[dependent]--
+			}
+		}
+		if !progressed {
+			return nil, CircularDependency{Cycle: cycleMembers(nodes, visited)}
+		}
+	}
+
+	return order, nil
+}
+
+// cycleMembers narrows the still-unvisited ids left over by a stalled
+// topoSort pass down to the ones actually on a cycle. A node can be stuck
+// with nonzero indegree merely because it depends (even transitively) on a
+// cycle without being part of it, so every id with zero stuck dependents -
+// nothing else stuck depends on it - is iteratively dropped until only
+// mutually-dependent ids remain.
+func cycleMembers(nodes []dagNode, visited map[string]bool) []string {
+	stuck := make(map[string]bool)
+	for _, n := range nodes {
+		if !visited[n.id] {
+			stuck[n.id] = true
+		}
+	}
+
+	for {
+		dependentCount := make(map[string]int, len(stuck))
+		for id := range stuck {
+			dependentCount[id] = 0
+		}
+		for _, n := range nodes {
+			if !stuck[n.id] {
+				continue
+			}
+			for _, dep := range n.deps {
+				if stuck[dep] {
+					dependentCount[dep]++
+				}
+			}
+		}
+
+		removed := false
+		for id, count := range dependentCount {
+			if count == 0 {
+				delete(stuck, id)
+				removed = true
+			}
+		}
+		if !removed {
+			break
+		}
+	}
+
+	cycle := make([]string, 0, len(stuck))
+	for id := range stuck {
+		cycle = append(cycle, id)
+	}
+	sort.Strings(cycle)
+	return cycle
+}
+
+// dagNodes flattens artifacts and applications into one combined graph:
+// applications can depend on artifact ids (via Artifacts) as well as other
+// application ids (via Dependencies), so both live in the same id space.
+func (c PipelineConfig) dagNodes() []dagNode {
+	nodes := make([]dagNode, 0, len(c.Artifacts)+len(c.Applications))
+
+	for _, spec := range c.Artifacts {
+		nodes = append(nodes, dagNode{id: spec.Id, deps: spec.Dependencies})
+	}
+
+	for _, spec := range c.Applications {
+		deps := make([]string, 0, len(spec.Artifacts)+len(spec.Dependencies))
+		deps = append(deps, spec.Artifacts...)
+		deps = append(deps, spec.Dependencies...)
+		nodes = append(nodes, dagNode{id: spec.Id, deps: deps})
+	}
+
+	return nodes
+}
+
+// TopoOrder returns every artifact and application id in dependency order -
+// each id appears only after everything it depends on. PipelineConfig.Validate
+// uses this to reject an invalid dependency graph up front.
+func (c PipelineConfig) TopoOrder() ([]string, error) {
+	return topoSort(c.dagNodes())
+}
+
+// jobId returns the id of a resolved Job, whichever concrete type backs it.
+func jobId(job Job) string {
+	switch j := job.(type) {
+	case Artifact:
+		return j.Id
+	case Application:
+		return j.Id
+	default:
+		return ""
+	}
+}
+
+// dagNodes flattens a ParsedConfig's already-resolved artifacts and
+// applications into the same combined graph PipelineConfig.dagNodes builds
+// from specs, reading each one's dependency ids off its Upstreams instead.
+// Nodes are listed artifacts first, then applications, each sorted by id, so
+// topoSort's tie-breaking stays deterministic.
+func (c ParsedConfig) dagNodes() []dagNode {
+	nodes := make([]dagNode, 0, len(c.Artifacts)+len(c.Applications))
+
+	for _, id := range sortedArtifactIds(c) {
+		artifact := c.Artifacts[id]
+		deps := make([]string, 0, len(artifact.Upstreams))
+		for _, upstream := range artifact.Upstreams {
+			deps = append(deps, jobId(upstream))
+		}
+		nodes = append(nodes, dagNode{id: id, deps: deps})
+	}
+
+	for _, id := range sortedApplicationIds(c) {
+		application := c.Applications[id]
+		deps := make([]string, 0, len(application.Upstreams))
+		for _, upstream := range application.Upstreams {
+			deps = append(deps, jobId(upstream))
+		}
+		nodes = append(nodes, dagNode{id: id, deps: deps})
+	}
+
+	return nodes
+}
+
+// TopoOrder returns every artifact and application id in dependency order -
+// each id appears only after everything it depends on. Generators that need
+// to emit jobs in dependency order (e.g. a Tekton Task runAfter chain) use
+// this instead of declaration order.
+func (c ParsedConfig) TopoOrder() ([]string, error) {
+	return topoSort(c.dagNodes())
+}
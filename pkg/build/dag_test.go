@@ -0,0 +1,89 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopoOrderForwardReference(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "svc", Path: "./svc", Dependencies: []string{"base"}},
+			{Id: "base", Path: "./base"},
+		},
+	}
+
+	order, err := config.TopoOrder()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"base", "svc"}, order)
+}
+
+func TestTopoOrderDiamondDependency(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "base", Path: "./base"},
+			{Id: "left", Path: "./left", Dependencies: []string{"base"}},
+			{Id: "right", Path: "./right", Dependencies: []string{"base"}},
+		},
+		Applications: []ApplicationSpec{
+			{Id: "app", Path: "./app", Dependencies: nil, Artifacts: []string{"left", "right"}},
+		},
+	}
+
+	order, err := config.TopoOrder()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"base", "left", "right", "app"}, order)
+}
+
+func TestTopoOrderUnknownDependency(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "svc", Path: "./svc", Dependencies: []string{"missing"}},
+		},
+	}
+
+	_, err := config.TopoOrder()
+	assert.Equal(t, UnknownDependency{Id: "svc", Dependency: "missing"}, err)
+}
+
+func TestTopoOrderCircularDependency(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "a", Path: "./a", Dependencies: []string{"b"}},
+			{Id: "b", Path: "./b", Dependencies: []string{"a"}},
+		},
+	}
+
+	_, err := config.TopoOrder()
+	assert.Equal(t, CircularDependency{Cycle: []string{"a", "b"}}, err)
+}
+
+func TestTopoOrderCircularDependencyExcludesDownstreamNodes(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "a", Path: "./a", Dependencies: []string{"b"}},
+			{Id: "b", Path: "./b", Dependencies: []string{"a"}},
+		},
+		Applications: []ApplicationSpec{
+			{Id: "app", Path: "./app", Artifacts: []string{"a"}},
+		},
+	}
+
+	_, err := config.TopoOrder()
+	assert.Equal(t, CircularDependency{Cycle: []string{"a", "b"}}, err)
+}
+
+func TestTopoOrderDuplicateId(t *testing.T) {
+	config := PipelineConfig{
+		Artifacts: []ArtifactSpec{
+			{Id: "svc", Path: "./svc"},
+		},
+		Applications: []ApplicationSpec{
+			{Id: "svc", Path: "./app"},
+		},
+	}
+
+	_, err := config.TopoOrder()
+	assert.Equal(t, DuplicateId{Id: "svc"}, err)
+}
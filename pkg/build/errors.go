@@ -0,0 +1,5 @@
+package build
+
+import "fmt"
+
+var eMissingRequiredField = fmt.Errorf("missing required field")
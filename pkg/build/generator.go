@@ -0,0 +1,399 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/itura/fun/pkg/fun"
+
+	"gopkg.in/yaml.v3"
+)
+
+type PipelineTarget string
+
+var (
+	targetGithubActions PipelineTarget = "github-actions"
+	targetGitlabCI      PipelineTarget = "gitlab-ci"
+	targetCircleCI      PipelineTarget = "circleci"
+	targetTekton        PipelineTarget = "tekton"
+)
+
+var PipelineTargetEnum = fun.NewEnum(targetGithubActions, targetGitlabCI, targetCircleCI, targetTekton)
+
+// PipelineGenerator turns a ParsedConfig into the native pipeline definition
+// for a specific CI backend.
+type PipelineGenerator interface {
+	Generate(config ParsedConfig) ([]byte, error)
+	Filename() string
+}
+
+var generators = map[PipelineTarget]PipelineGenerator{
+	targetGithubActions: GithubActionsGenerator{},
+	targetGitlabCI:      GitlabCIGenerator{},
+	targetCircleCI:      CircleCIGenerator{},
+	targetTekton:        TektonGenerator{},
+}
+
+// GeneratorFor resolves the pipeline.target YAML field to its generator,
+// defaulting to GitHub Actions for configs written before this field existed.
+func GeneratorFor(target PipelineTarget) (PipelineGenerator, error) {
+	if target == "" {
+		target = targetGithubActions
+	}
+
+	generator, ok := generators[target]
+	if !ok {
+		return nil, PipelineTargetEnum.InvalidEnumValue(string(target))
+	}
+
+	return generator, nil
+}
+
+func sortedArtifactIds(config ParsedConfig) []string {
+	ids := make([]string, 0, len(config.Artifacts))
+	for id := range config.Artifacts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedApplicationIds(config ParsedConfig) []string {
+	ids := make([]string, 0, len(config.Applications))
+	for id := range config.Applications {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// artifactSteps returns the build-then-publish steps registered for the
+// artifact's type, so every generator emits the same commands instead of
+// just the generic change-detection gate.
+func artifactSteps(artifact Artifact) []Step {
+	plugin, ok := artifactTypePlugins[artifact.Type]
+	if !ok {
+		return nil
+	}
+	return append(plugin.BuildSteps(artifact), plugin.PublishSteps(artifact)...)
+}
+
+// applicationSecretSteps returns the steps that fetch every secret an
+// application was assigned, one provider's FetchSteps at a time in a
+// deterministic (sorted by provider id) order.
+func applicationSecretSteps(application Application) []Step {
+	providerIds := make([]string, 0, len(application.Secrets))
+	for providerId := range application.Secrets {
+		providerIds = append(providerIds, providerId)
+	}
+	sort.Strings(providerIds)
+
+	var steps []Step
+	for _, providerId := range providerIds {
+		if provider, ok := application.SecretProviders.find(providerId); ok {
+			steps = append(steps, provider.FetchSteps(application.Secrets[providerId])...)
+		}
+	}
+	return steps
+}
+
+// applicationSteps returns every step an application's deploy job needs:
+// first logging into its cluster, then fetching every secret it was
+// assigned. Every generator uses this so none of them deploy without
+// authenticating first.
+func applicationSteps(application Application) []Step {
+	steps := []Step{application.CloudProvider.KubernetesLoginStep()}
+	return append(steps, applicationSecretSteps(application)...)
+}
+
+// scripts filters steps down to the ones that have a shell command,
+// rendering them for backends (GitLab, CircleCI) that only support inline
+// scripts; Tekton uses tektonSteps instead. See Step.Script for why a step
+// can have none.
+func scripts(steps []Step) []string {
+	out := make([]string, 0, len(steps))
+	for _, s := range steps {
+		if cmd, ok := s.Script(); ok {
+			out = append(out, cmd)
+		}
+	}
+	return out
+}
+
+// tektonSteps renders steps as Tekton Task step entries.
+func tektonSteps(steps []Step) []map[string]any {
+	var out []map[string]any
+	for _, s := range steps {
+		if cmd, ok := s.Script(); ok {
+			out = append(out, map[string]any{"name": tektonStepName(s.Name), "script": cmd})
+		}
+	}
+	return out
+}
+
+// tektonStepName slugifies a Step's human-readable Name into the
+// lowercase-with-dashes form Tekton requires for step names.
+func tektonStepName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
+
+// tektonRunAfter maps every artifact/application id to the Task names it
+// must runAfter: its own upstream ids if it has any, otherwise just "diff".
+func tektonRunAfter(config ParsedConfig) map[string][]string {
+	runAfter := make(map[string][]string)
+	for _, n := range config.dagNodes() {
+		if len(n.deps) == 0 {
+			runAfter[n.id] = []string{"diff"}
+		} else {
+			runAfter[n.id] = n.deps
+		}
+	}
+	return runAfter
+}
+
+// GithubActionsGenerator emits the workflow this repo has always produced:
+// one paths-filter-gated job per artifact and application.
+type GithubActionsGenerator struct{}
+
+func (g GithubActionsGenerator) Filename() string {
+	return ".github/workflows/pipeline.yaml"
+}
+
+func (g GithubActionsGenerator) Generate(config ParsedConfig) ([]byte, error) {
+	jobs := make(map[string]any)
+	artifactIds := sortedArtifactIds(config)
+	applicationIds := sortedApplicationIds(config)
+
+	var filterLines []string
+	outputs := make(map[string]string, len(artifactIds)+len(applicationIds))
+	for _, id := range artifactIds {
+		filterLines = append(filterLines, fmt.Sprintf("%s: %s", id, config.Artifacts[id].Path))
+		outputs[id] = fmt.Sprintf("${{ steps.filter.outputs.%s }}", id)
+	}
+	for _, id := range applicationIds {
+		filterLines = append(filterLines, fmt.Sprintf("%s: %s", id, config.Applications[id].Path))
+		outputs[id] = fmt.Sprintf("${{ steps.filter.outputs.%s }}", id)
+	}
+
+	// changes runs paths-filter once for every id and exposes one output per
+	// id, so every downstream job can gate on needs.changes.outputs.<id>
+	// instead of each re-running its own filter.
+	jobs["changes"] = map[string]any{
+		"outputs": outputs,
+		"steps": []map[string]any{
+			{"id": "filter", "uses": "dorny/paths-filter@v3", "with": map[string]string{"filters": strings.Join(filterLines, "\n")}},
+		},
+	}
+
+	for _, id := range artifactIds {
+		artifact := config.Artifacts[id]
+		var steps []map[string]any
+		for _, s := range artifactSteps(artifact) {
+			steps = append(steps, s.GithubStep())
+		}
+		jobs[id] = map[string]any{
+			"needs": []string{"changes"},
+			"if":    fmt.Sprintf("needs.changes.outputs.%s == 'true'", id),
+			"steps": steps,
+		}
+	}
+
+	for _, id := range applicationIds {
+		application := config.Applications[id]
+		var steps []map[string]any
+		for _, s := range applicationSteps(application) {
+			steps = append(steps, s.GithubStep())
+		}
+		jobs[id] = map[string]any{
+			"needs": []string{"changes"},
+			"if":    fmt.Sprintf("needs.changes.outputs.%s == 'true'", id),
+			"steps": steps,
+		}
+	}
+
+	return yaml.Marshal(map[string]any{
+		"name": config.BuildName,
+		"on":   []string{"push"},
+		"jobs": jobs,
+	})
+}
+
+// gitlabJobName namespaces a job key by its stage, e.g. "build:svc". An
+// artifact and an application can't collide on a bare id this way, and the
+// colon keeps it clear of GitLab's reserved top-level keys (stages,
+// variables, workflow, ...), which a bare id could otherwise match.
+func gitlabJobName(stage, id string) string {
+	return fmt.Sprintf("%s:%s", stage, id)
+}
+
+// GitlabCIGenerator translates the same per-id change detection into
+// rules: changes: on each stage's job.
+type GitlabCIGenerator struct{}
+
+func (g GitlabCIGenerator) Filename() string {
+	return ".gitlab-ci.yml"
+}
+
+func (g GitlabCIGenerator) Generate(config ParsedConfig) ([]byte, error) {
+	doc := map[string]any{
+		"stages": []string{"build", "deploy"},
+	}
+
+	for _, id := range sortedArtifactIds(config) {
+		artifact := config.Artifacts[id]
+		doc[gitlabJobName("build", id)] = map[string]any{
+			"stage": "build",
+			"rules": []map[string]any{
+				{"changes": []string{artifact.Path}},
+			},
+			"script": scripts(artifactSteps(artifact)),
+		}
+	}
+
+	for _, id := range sortedApplicationIds(config) {
+		application := config.Applications[id]
+		doc[gitlabJobName("deploy", id)] = map[string]any{
+			"stage": "deploy",
+			"rules": []map[string]any{
+				{"changes": []string{application.Path}},
+			},
+			"script": scripts(applicationSteps(application)),
+		}
+	}
+
+	return yaml.Marshal(doc)
+}
+
+// circleciRunParameter is the pipeline parameter name the path-filtering orb
+// sets to true when id's path has changed.
+func circleciRunParameter(id string) string {
+	return fmt.Sprintf("run-%s", id)
+}
+
+// CircleCIGenerator emits jobs under the top-level jobs: map CircleCI
+// requires, one single-job workflow per id gated by a `when` on the pipeline
+// parameter that id's path-filtering mapping line sets, and a detect-changes
+// workflow that actually runs the path-filtering orb to set them - a
+// `when` condition only gates a whole workflow, not one job inside a shared
+// one, so each id needs its own.
+type CircleCIGenerator struct{}
+
+func (g CircleCIGenerator) Filename() string {
+	return ".circleci/config.yml"
+}
+
+func (g CircleCIGenerator) Generate(config ParsedConfig) ([]byte, error) {
+	jobs := make(map[string]any)
+	workflows := make(map[string]any)
+	parameters := make(map[string]any)
+	var mappingLines []string
+
+	addJob := func(id, path string, steps []Step) {
+		var circleSteps []map[string]any
+		for _, cmd := range scripts(steps) {
+			circleSteps = append(circleSteps, map[string]any{"run": cmd})
+		}
+		jobs[id] = map[string]any{"steps": circleSteps}
+
+		param := circleciRunParameter(id)
+		parameters[param] = map[string]any{"type": "boolean", "default": false}
+		mappingLines = append(mappingLines, fmt.Sprintf("%s %s true", path, param))
+
+		workflows[id] = map[string]any{
+			"when": fmt.Sprintf("<< pipeline.parameters.%s >>", param),
+			"jobs": []string{id},
+		}
+	}
+
+	for _, id := range sortedArtifactIds(config) {
+		artifact := config.Artifacts[id]
+		addJob(id, artifact.Path, artifactSteps(artifact))
+	}
+
+	for _, id := range sortedApplicationIds(config) {
+		application := config.Applications[id]
+		addJob(id, application.Path, applicationSteps(application))
+	}
+
+	workflows["detect-changes"] = map[string]any{
+		"jobs": []map[string]any{
+			{
+				"path-filtering/filter": map[string]any{
+					"mapping":     strings.Join(mappingLines, "\n"),
+					"config-path": g.Filename(),
+				},
+			},
+		},
+	}
+
+	return yaml.Marshal(map[string]any{
+		"version": 2.1,
+		"orbs": map[string]string{
+			"path-filtering": "circleci/path-filtering@1",
+		},
+		"parameters": parameters,
+		"jobs":       jobs,
+		"workflows":  workflows,
+	})
+}
+
+// TektonGenerator emits a PipelineRun whose Tasks are individually gated by
+// `when` expressions over a shared git-clone + diff workspace.
+type TektonGenerator struct{}
+
+func (g TektonGenerator) Filename() string {
+	return "tekton/pipelinerun.yaml"
+}
+
+func (g TektonGenerator) Generate(config ParsedConfig) ([]byte, error) {
+	tasks := []map[string]any{
+		{"name": "git-clone", "taskRef": map[string]string{"name": "git-clone"}},
+		{"name": "diff", "taskRef": map[string]string{"name": "changed-paths"}, "runAfter": []string{"git-clone"}},
+	}
+
+	order, err := config.TopoOrder()
+	if err != nil {
+		return nil, err
+	}
+	runAfter := tektonRunAfter(config)
+
+	for _, id := range order {
+		if artifact, ok := config.Artifacts[id]; ok {
+			task := map[string]any{
+				"name":     id,
+				"runAfter": runAfter[id],
+				"when": []map[string]any{
+					{"input": "$(tasks.diff.results.changed-files)", "operator": "in", "values": []string{artifact.Path}},
+				},
+			}
+			if steps := tektonSteps(artifactSteps(artifact)); len(steps) > 0 {
+				task["taskSpec"] = map[string]any{"steps": steps}
+			}
+			tasks = append(tasks, task)
+			continue
+		}
+
+		application := config.Applications[id]
+		task := map[string]any{
+			"name":     id,
+			"runAfter": runAfter[id],
+			"when": []map[string]any{
+				{"input": "$(tasks.diff.results.changed-files)", "operator": "in", "values": []string{application.Path}},
+			},
+		}
+		if steps := tektonSteps(applicationSteps(application)); len(steps) > 0 {
+			task["taskSpec"] = map[string]any{"steps": steps}
+		}
+		tasks = append(tasks, task)
+	}
+
+	return yaml.Marshal(map[string]any{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "PipelineRun",
+		"metadata":   map[string]string{"generateName": config.BuildName + "-"},
+		"spec": map[string]any{
+			"pipelineSpec": map[string]any{"tasks": tasks},
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package build
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineGenerators(t *testing.T) {
+	cases := []struct {
+		target PipelineTarget
+		golden string
+	}{
+		{targetGithubActions, "test_fixtures/golden/github_actions.yaml"},
+		{targetGitlabCI, "test_fixtures/golden/gitlab_ci.yaml"},
+		{targetCircleCI, "test_fixtures/golden/circleci.yaml"},
+		{targetTekton, "test_fixtures/golden/tekton.yaml"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.target), func(t *testing.T) {
+			result, err := ParseConfigForGeneration("test_fixtures/valid_pipeline_config.yaml", "???")
+			assert.Nil(t, err)
+
+			generator, err := GeneratorFor(tc.target)
+			assert.Nil(t, err)
+
+			actual, err := generator.Generate(result)
+			assert.Nil(t, err)
+
+			expected, err := os.ReadFile(tc.golden)
+			assert.Nil(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestGeneratorForDefaultsToGithubActions(t *testing.T) {
+	generator, err := GeneratorFor("")
+	assert.Nil(t, err)
+	assert.Equal(t, GithubActionsGenerator{}, generator)
+}
+
+func TestGeneratorForUnknownTarget(t *testing.T) {
+	_, err := GeneratorFor("jenkins")
+	assert.Equal(t, PipelineTargetEnum.InvalidEnumValue("jenkins"), err)
+}
@@ -0,0 +1,154 @@
+package build
+
+import "fmt"
+
+// SecretProviderPlugin supplies the behavior for one SecretProviderType: the
+// config fields it requires and how to fetch a named secret. Mirrors
+// ArtifactTypePlugin/CloudProviderPlugin's external registry pattern instead
+// of switching on type inline.
+type SecretProviderPlugin interface {
+	RequiredFields() []string
+	FetchStep(config SecretProviderConfig, secretName string) Step
+}
+
+var secretProviderPlugins = map[SecretProviderType]SecretProviderPlugin{}
+
+// RegisterSecretProvider adds or replaces the plugin used for secret
+// providers of the given type.
+func RegisterSecretProvider(name string, plugin SecretProviderPlugin) {
+	secretProviderPlugins[SecretProviderType(name)] = plugin
+}
+
+func init() {
+	RegisterSecretProvider(string(secretProviderTypeGcp), gcpSecretProvider{})
+	RegisterSecretProvider(string(secretProviderTypeGithub), githubSecretProvider{})
+	RegisterSecretProvider(string(secretProviderTypeVault), vaultSecretProvider{})
+	RegisterSecretProvider(string(secretProviderTypeAwsSecretsManager), awsSecretsManagerProvider{})
+	RegisterSecretProvider(string(secretProviderTypeAzureKeyVault), azureKeyVaultProvider{})
+	RegisterSecretProvider(string(secretProviderTypeExec), execSecretProvider{})
+}
+
+// FetchStep returns the CI step that pulls secretName into the job
+// environment using this provider's backend.
+func (s SecretProviderConfig) FetchStep(secretName string) Step {
+	if plugin, ok := secretProviderPlugins[s.Type]; ok {
+		return plugin.FetchStep(s, secretName)
+	}
+	return Step{}
+}
+
+// HelmArg returns the `helm upgrade --set` argument that wires a fetched
+// secret into the given values key.
+func (s SecretProviderConfig) HelmArg(key, secretName string) string {
+	return fmt.Sprintf("--set %s=$%s", key, secretName)
+}
+
+// FetchSteps and HelmArgs apply FetchStep/HelmArg across every secret this
+// provider was assigned, so callers (the generators) don't hard-code any one
+// provider's invocation when wiring up an application's secrets.
+func (s SecretProviderConfig) FetchSteps(values []HelmSecretValue) []Step {
+	steps := make([]Step, 0, len(values))
+	for _, v := range values {
+		steps = append(steps, s.FetchStep(v.SecretName))
+	}
+	return steps
+}
+
+func (s SecretProviderConfig) HelmArgs(values []HelmSecretValue) []string {
+	args := make([]string, 0, len(values))
+	for _, v := range values {
+		args = append(args, s.HelmArg(v.HelmKey, v.SecretName))
+	}
+	return args
+}
+
+type vaultSecretProvider struct{}
+
+func (vaultSecretProvider) RequiredFields() []string {
+	return []string{"address", "role"}
+}
+
+func (vaultSecretProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s from Vault", secretName),
+		Uses: "hashicorp/vault-action@v3",
+		With: map[string]string{
+			"url":     config.Config["address"],
+			"role":    config.Config["role"],
+			"method":  "jwt",
+			"secrets": secretName,
+		},
+	}
+}
+
+type awsSecretsManagerProvider struct{}
+
+func (awsSecretsManagerProvider) RequiredFields() []string {
+	return []string{"region"}
+}
+
+func (awsSecretsManagerProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s from Secrets Manager", secretName),
+		Uses: "aws-actions/aws-secretsmanager-get-secrets@v2",
+		With: map[string]string{"secret-ids": secretName},
+	}
+}
+
+type azureKeyVaultProvider struct{}
+
+func (azureKeyVaultProvider) RequiredFields() []string {
+	return []string{"vaultName"}
+}
+
+func (azureKeyVaultProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s from Key Vault", secretName),
+		Uses: "azure/get-keyvault-secrets@v1",
+		With: map[string]string{
+			"vault-name": config.Config["vaultName"],
+			"secrets":    secretName,
+		},
+	}
+}
+
+type execSecretProvider struct{}
+
+func (execSecretProvider) RequiredFields() []string {
+	return []string{"command"}
+}
+
+func (execSecretProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s", secretName),
+		Run:  fmt.Sprintf("%s %s", config.Config["command"], secretName),
+	}
+}
+
+type githubSecretProvider struct{}
+
+func (githubSecretProvider) RequiredFields() []string {
+	return nil
+}
+
+func (githubSecretProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s from repo secrets", secretName),
+		Run:  fmt.Sprintf("echo \"%s=${{ secrets.%s }}\" >> $GITHUB_ENV", secretName, secretName),
+	}
+}
+
+// gcpSecretProvider is the default/native provider: GCP Secret Manager.
+type gcpSecretProvider struct{}
+
+func (gcpSecretProvider) RequiredFields() []string {
+	return []string{"project"}
+}
+
+func (gcpSecretProvider) FetchStep(config SecretProviderConfig, secretName string) Step {
+	return Step{
+		Name: fmt.Sprintf("Fetch %s from Secret Manager", secretName),
+		Uses: "google-github-actions/get-secretmanager-secrets@v2",
+		With: map[string]string{"secrets": secretName},
+	}
+}
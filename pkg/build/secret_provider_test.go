@@ -0,0 +1,72 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/itura/fun/pkg/fun"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretProviderFetchStep(t *testing.T) {
+	vault := SecretProviderConfig{
+		Type: secretProviderTypeVault,
+		Config: fun.NewConfig[string]().
+			Set("address", "https://vault.internal").
+			Set("role", "ci"),
+	}
+	assert.Equal(t, Step{
+		Name: "Fetch db-password from Vault",
+		Uses: "hashicorp/vault-action@v3",
+		With: map[string]string{
+			"url":     "https://vault.internal",
+			"role":    "ci",
+			"method":  "jwt",
+			"secrets": "db-password",
+		},
+	}, vault.FetchStep("db-password"))
+
+	aws := SecretProviderConfig{Type: secretProviderTypeAwsSecretsManager}
+	assert.Equal(t, Step{
+		Name: "Fetch db-password from Secrets Manager",
+		Uses: "aws-actions/aws-secretsmanager-get-secrets@v2",
+		With: map[string]string{"secret-ids": "db-password"},
+	}, aws.FetchStep("db-password"))
+}
+
+func TestSecretProviderConfigsWithMixedTypesValidate(t *testing.T) {
+	providers := SecretProviderConfigs{
+		SecretProviderConfig{
+			Id:   "vault-main",
+			Type: secretProviderTypeVault,
+			Config: fun.NewConfig[string]().
+				Set("address", "https://vault.internal").
+				Set("role", "ci"),
+			SecretNames: []string{"db-password"},
+		},
+		SecretProviderConfig{
+			Id:          "aws-secrets",
+			Type:        secretProviderTypeAwsSecretsManager,
+			Config:      fun.NewConfig[string]().Set("region", "us-east-1"),
+			SecretNames: []string{"api-key"},
+		},
+	}
+
+	for i, provider := range providers {
+		errs := provider.Validate(string(rune('0' + i)))
+		assert.Equal(t, false, errs.IsPresent())
+	}
+}
+
+func TestSecretProviderValidateMissingConfig(t *testing.T) {
+	provider := SecretProviderConfig{
+		Id:          "vault-main",
+		Type:        secretProviderTypeVault,
+		SecretNames: []string{"db-password"},
+	}
+	errs := provider.Validate("0")
+	assert.Equal(t, true, errs.IsPresent())
+	assert.Equal(t,
+		NewValidationErrors("0").Put("config", eMissingRequiredField),
+		errs,
+	)
+}
@@ -0,0 +1,44 @@
+package build
+
+// Step is a single executable unit within a generated CI job, e.g. a GitHub
+// Actions step or the Tekton/GitLab/CircleCI equivalent. Generators translate
+// a Step into their own native shape.
+type Step struct {
+	Name string
+	Uses string
+	With map[string]string
+	Run  string
+	Env  map[string]string
+}
+
+// GithubStep renders this Step as the step map GitHub Actions expects,
+// omitting any field that wasn't set. A step that has both Uses and Run
+// (e.g. a cloud login, which also carries a Run fallback for backends
+// without marketplace actions - see Script) prefers Uses on GitHub, since a
+// single step can't do both.
+func (s Step) GithubStep() map[string]any {
+	step := map[string]any{}
+	if s.Name != "" {
+		step["name"] = s.Name
+	}
+	if s.Uses != "" {
+		step["uses"] = s.Uses
+		if s.With != nil {
+			step["with"] = s.With
+		}
+	} else if s.Run != "" {
+		step["run"] = s.Run
+	}
+	if s.Env != nil {
+		step["env"] = s.Env
+	}
+	return step
+}
+
+// Script returns the shell command this step runs, and whether it has one.
+// GitLab, CircleCI and Tekton only understand inline scripts, not GitHub's
+// marketplace actions, so a Step defined via Uses/With (e.g. a cloud login)
+// needs a Run fallback for those backends to run anything at all.
+func (s Step) Script() (string, bool) {
+	return s.Run, s.Run != ""
+}